@@ -0,0 +1,34 @@
+package https
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+
+	"github.com/pkg/errors"
+)
+
+// LoadClientTLSConfig constructs a tls.Config suitable for mutual TLS,
+// loading the client certificate/key pair from certPath/keyPath and
+// trusting caCertPath as the server's certificate authority.
+func LoadClientTLSConfig(certPath, keyPath, caCertPath string) (*tls.Config, error) {
+	certificate, err := tls.LoadX509KeyPair(certPath, keyPath)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to load client certificate")
+	}
+
+	caCert, err := ioutil.ReadFile(caCertPath)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to read CA certificate")
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caCert) {
+		return nil, errors.New("unable to parse CA certificate")
+	}
+
+	return &tls.Config{
+		Certificates: []tls.Certificate{certificate},
+		RootCAs:      pool,
+	}, nil
+}
@@ -0,0 +1,76 @@
+// Package https implements a transport that tunnels the Mutagen endpoint
+// stream over HTTP/2, for deployments that can expose HTTPS but not SSH
+// (corporate proxies, Kubernetes ingress, and similar).
+package https
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/pkg/errors"
+	"golang.org/x/net/http2"
+
+	urlpkg "github.com/havoc-io/mutagen/url"
+)
+
+// Dial opens a duplex endpoint stream to a Mutagen agent HTTP server
+// described by url, authenticating with bearerToken (if non-empty) or with
+// the client certificate embedded in tlsConfig (for mTLS). ctx may be used
+// to cancel the dial.
+func Dial(ctx context.Context, url *urlpkg.URL, bearerToken string, tlsConfig *tls.Config) (io.ReadWriteCloser, error) {
+	address := url.Hostname
+	if url.Port != 0 {
+		address = fmt.Sprintf("%s:%d", address, url.Port)
+	}
+
+	client := &http.Client{
+		Transport: &http2.Transport{TLSClientConfig: tlsConfig},
+	}
+
+	// The request body is the client-to-server half of the stream; we write
+	// to it via pw and let the HTTP/2 transport read from pr as the request
+	// proceeds.
+	pr, pw := io.Pipe()
+	endpoint := fmt.Sprintf("https://%s/endpoint%s", address, url.Path)
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, pr)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to construct endpoint request")
+	}
+	if bearerToken != "" {
+		request.Header.Set("Authorization", "Bearer "+bearerToken)
+	}
+
+	response, err := client.Do(request)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to dial HTTPS endpoint")
+	} else if response.StatusCode != http.StatusOK {
+		response.Body.Close()
+		return nil, errors.Errorf("HTTPS endpoint returned status %d", response.StatusCode)
+	}
+
+	return &stream{reader: response.Body, writer: pw}, nil
+}
+
+// stream adapts the client side of an HTTP/2 request/response pair (the
+// request body for writes, the response body for reads) into a single
+// io.ReadWriteCloser representing the endpoint stream.
+type stream struct {
+	reader io.ReadCloser
+	writer *io.PipeWriter
+}
+
+func (s *stream) Read(p []byte) (int, error) { return s.reader.Read(p) }
+
+func (s *stream) Write(p []byte) (int, error) { return s.writer.Write(p) }
+
+func (s *stream) Close() error {
+	writeErr := s.writer.Close()
+	readErr := s.reader.Close()
+	if writeErr != nil {
+		return writeErr
+	}
+	return readErr
+}
@@ -0,0 +1,77 @@
+package https
+
+import (
+	"crypto/subtle"
+	"io"
+	"net/http"
+)
+
+// EndpointHandler processes a single endpoint connection, speaking whatever
+// RPC protocol newRemoteEndpoint uses on the wire. It takes ownership of
+// stream and is responsible for closing it when finished.
+type EndpointHandler func(stream io.ReadWriteCloser) error
+
+// Server serves the Mutagen agent endpoint RPC surface over HTTP/2,
+// allowing session.connect to reach it via Dial in deployments where an SSH
+// port can't be exposed but HTTPS can.
+type Server struct {
+	// Handler processes each incoming endpoint connection.
+	Handler EndpointHandler
+	// BearerToken, if non-empty, is the token clients must present via the
+	// Authorization header. If empty, authentication is expected to be
+	// handled by mTLS at the TLS layer instead.
+	BearerToken string
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if s.BearerToken != "" && !constantTimeEqual(r.Header.Get("Authorization"), "Bearer "+s.BearerToken) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	stream := &serverStream{reader: r.Body, writer: w, flusher: flusher}
+	s.Handler(stream)
+}
+
+// constantTimeEqual reports whether a and b are equal using a constant-time
+// comparison, so that checking a bearer token doesn't leak timing
+// information about how many leading bytes matched.
+func constantTimeEqual(a, b string) bool {
+	return len(a) == len(b) && subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
+
+// serverStream adapts the server side of an HTTP/2 request/response pair
+// into a single io.ReadWriteCloser.
+type serverStream struct {
+	reader  io.ReadCloser
+	writer  http.ResponseWriter
+	flusher http.Flusher
+}
+
+func (s *serverStream) Read(p []byte) (int, error) { return s.reader.Read(p) }
+
+func (s *serverStream) Write(p []byte) (int, error) {
+	n, err := s.writer.Write(p)
+	if err == nil {
+		s.flusher.Flush()
+	}
+	return n, err
+}
+
+func (s *serverStream) Close() error {
+	return s.reader.Close()
+}
@@ -10,6 +10,8 @@ func (u *URL) Format() string {
 		return u.formatLocal()
 	} else if u.Protocol == Protocol_SSH {
 		return u.formatSSH()
+	} else if u.Protocol == Protocol_HTTPS {
+		return u.formatHTTPS()
 	} else if u.Protocol == Protocol_Custom {
 		return u.formatCustom()
 	}
@@ -43,6 +45,28 @@ func (u *URL) formatSSH() string {
 	return result
 }
 
+// formatHTTPS formats an HTTPS URL.
+func (u *URL) formatHTTPS() string {
+	// Create the base result.
+	result := u.Hostname
+
+	// Add username if present.
+	if u.Username != "" {
+		result = fmt.Sprintf("%s@%s", u.Username, result)
+	}
+
+	// Add port if present.
+	if u.Port != 0 {
+		result = fmt.Sprintf("%s:%d", result, u.Port)
+	}
+
+	// Add scheme and path.
+	result = fmt.Sprintf("https://%s%s", result, u.Path)
+
+	// Done.
+	return result
+}
+
 // formatCustom formats a custom URL. We treat custom URLs as opaque and simply
 // return their value.
 func (u *URL) formatCustom() string {
@@ -0,0 +1,178 @@
+package url
+
+import (
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// schemeURLRegex matches a leading "scheme://" prefix, where scheme is any
+// run of characters other than ':' and '/' (whether or not it's a lexically
+// valid scheme), so that malformed scheme-qualified URLs can be rejected with a
+// clear error instead of being silently reinterpreted as SCP-style
+// specifications.
+var schemeURLRegex = regexp.MustCompile(`^([^:/]*)://`)
+
+// validSchemeRegex enforces RFC 3986's scheme grammar:
+// ALPHA *( ALPHA / DIGIT / "+" / "-" / "." ).
+var validSchemeRegex = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9+.-]*$`)
+
+// reservedSchemes lists scheme names that are handled by a built-in
+// transport and so can never be claimed by a third-party Protocol_Custom
+// transport (see url.URL.Scheme, which session.connect uses to key its
+// transport registry). "https" and "ssh" are parsed into their own
+// fully-structured protocols below; "file" has no scheme-qualified form at
+// all (use a plain path or user@host:path instead) but is reserved anyway
+// since Scheme() reports it for Protocol_Local.
+var reservedSchemes = map[string]bool{
+	"https": true,
+	"ssh":   true,
+	"file":  true,
+}
+
+// Parse parses a raw endpoint URL into its structured representation.
+// Scheme-qualified URLs (scheme://...) are recognized first: https:// and
+// ssh:// are parsed into their own fully-structured protocols, any other
+// reserved scheme is rejected outright (to avoid silently colliding with a
+// built-in transport), and any other valid scheme is treated as opaque
+// (Protocol_Custom), leaving the entire raw value in Path for whatever
+// transport is registered to interpret. Anything else is parsed as either a
+// local path or an SCP-style user@host:port:path specification.
+func Parse(raw string) (*URL, error) {
+	if raw == "" {
+		return nil, errors.New("empty raw URL")
+	}
+
+	if match := schemeURLRegex.FindStringSubmatch(raw); match != nil {
+		scheme := match[1]
+		if !validSchemeRegex.MatchString(scheme) {
+			return nil, errors.Errorf("invalid URL scheme: %q", scheme)
+		}
+		lower := strings.ToLower(scheme)
+		if lower == "https" {
+			return parseHTTPS(raw)
+		} else if lower == "ssh" {
+			return parseSSHScheme(raw)
+		} else if reservedSchemes[lower] {
+			return nil, errors.Errorf("%q is a reserved URL scheme and has no scheme-qualified form", scheme)
+		}
+		return &URL{Protocol: Protocol_Custom, Path: raw}, nil
+	}
+
+	return parseSCP(raw)
+}
+
+// parseHTTPS parses a scheme-qualified https:// URL into its structured
+// Protocol_HTTPS representation.
+func parseHTTPS(raw string) (*URL, error) {
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to parse HTTPS URL")
+	} else if parsed.Hostname() == "" {
+		return nil, errors.New("HTTPS URL missing hostname")
+	}
+
+	var port uint32
+	if portString := parsed.Port(); portString != "" {
+		p, err := strconv.ParseUint(portString, 10, 16)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid port in HTTPS URL")
+		}
+		port = uint32(p)
+	}
+
+	username := ""
+	if parsed.User != nil {
+		username = parsed.User.Username()
+	}
+
+	return &URL{
+		Protocol: Protocol_HTTPS,
+		Username: username,
+		Hostname: parsed.Hostname(),
+		Port:     port,
+		Path:     parsed.Path,
+	}, nil
+}
+
+// parseSSHScheme parses a scheme-qualified ssh://user@host:port/path URL
+// into its structured Protocol_SSH representation, mirroring parseHTTPS.
+// This is distinct from parseSCP's user@host:port:path form, which is
+// handled separately below.
+func parseSSHScheme(raw string) (*URL, error) {
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to parse SSH URL")
+	} else if parsed.Hostname() == "" {
+		return nil, errors.New("SSH URL missing hostname")
+	}
+
+	var port uint32
+	if portString := parsed.Port(); portString != "" {
+		p, err := strconv.ParseUint(portString, 10, 16)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid port in SSH URL")
+		}
+		port = uint32(p)
+	}
+
+	username := ""
+	if parsed.User != nil {
+		username = parsed.User.Username()
+	}
+
+	return &URL{
+		Protocol: Protocol_SSH,
+		Username: username,
+		Hostname: parsed.Hostname(),
+		Port:     port,
+		Path:     parsed.Path,
+	}, nil
+}
+
+// parseSCP parses a local path or an SCP-style user@host:port:path
+// specification, mirroring the disambiguation rules used by scp/rsync: a
+// string with no colon, or with a '/' before its first colon, is a local
+// path; otherwise everything before the first colon is the (optionally
+// user@-prefixed) hostname, and a numeric token immediately following that
+// colon (up to a further colon) is taken as the port.
+func parseSCP(raw string) (*URL, error) {
+	colonIndex := strings.IndexByte(raw, ':')
+	if colonIndex < 0 {
+		return &URL{Protocol: Protocol_Local, Path: raw}, nil
+	}
+	if slashIndex := strings.IndexByte(raw, '/'); slashIndex >= 0 && slashIndex < colonIndex {
+		return &URL{Protocol: Protocol_Local, Path: raw}, nil
+	}
+
+	username := ""
+	hostname := raw[:colonIndex]
+	if index := strings.IndexByte(hostname, '@'); index >= 0 {
+		username = hostname[:index]
+		hostname = hostname[index+1:]
+	}
+	if hostname == "" {
+		return nil, errors.New("empty hostname")
+	}
+	remainder := raw[colonIndex+1:]
+
+	var port uint32
+	path := remainder
+	if portIndex := strings.IndexByte(remainder, ':'); portIndex >= 0 {
+		if p, err := strconv.ParseUint(remainder[:portIndex], 10, 16); err == nil {
+			port = uint32(p)
+			path = remainder[portIndex+1:]
+		}
+	}
+
+	return &URL{
+		Protocol: Protocol_SSH,
+		Username: username,
+		Hostname: hostname,
+		Port:     port,
+		Path:     path,
+	}, nil
+}
@@ -381,3 +381,98 @@ func TestParseCustom(t *testing.T) {
 	}
 	test.run(t)
 }
+
+func TestParseHTTPS(t *testing.T) {
+	test := parseTestCase{
+		raw: "https://example.org/path",
+		expected: &URL{
+			Protocol: Protocol_HTTPS,
+			Hostname: "example.org",
+			Path:     "/path",
+		},
+	}
+	test.run(t)
+}
+
+func TestParseHTTPSUsernamePort(t *testing.T) {
+	test := parseTestCase{
+		raw: "https://user@example.org:8443/path",
+		expected: &URL{
+			Protocol: Protocol_HTTPS,
+			Username: "user",
+			Hostname: "example.org",
+			Port:     8443,
+			Path:     "/path",
+		},
+	}
+	test.run(t)
+}
+
+func TestParseHTTPSUppercaseScheme(t *testing.T) {
+	test := parseTestCase{
+		raw: "HTTPS://example.org/path",
+		expected: &URL{
+			Protocol: Protocol_HTTPS,
+			Hostname: "example.org",
+			Path:     "/path",
+		},
+	}
+	test.run(t)
+}
+
+func TestParseSSHScheme(t *testing.T) {
+	test := parseTestCase{
+		raw: "ssh://example.org/path",
+		expected: &URL{
+			Protocol: Protocol_SSH,
+			Hostname: "example.org",
+			Path:     "/path",
+		},
+	}
+	test.run(t)
+}
+
+func TestParseSSHSchemeUsernamePort(t *testing.T) {
+	test := parseTestCase{
+		raw: "ssh://user@example.org:2222/path",
+		expected: &URL{
+			Protocol: Protocol_SSH,
+			Username: "user",
+			Hostname: "example.org",
+			Port:     2222,
+			Path:     "/path",
+		},
+	}
+	test.run(t)
+}
+
+func TestParseSSHSchemeUppercaseScheme(t *testing.T) {
+	test := parseTestCase{
+		raw: "SSH://example.org/path",
+		expected: &URL{
+			Protocol: Protocol_SSH,
+			Hostname: "example.org",
+			Path:     "/path",
+		},
+	}
+	test.run(t)
+}
+
+func TestParseSSHSchemeMissingHostnameInvalid(t *testing.T) {
+	test := parseTestCase{
+		raw:  "ssh:///path",
+		fail: true,
+	}
+	test.run(t)
+}
+
+func TestParseFileSchemeReservedInvalid(t *testing.T) {
+	// "file" has no scheme-qualified form (it's reserved since Scheme()
+	// reports it for Protocol_Local) and must not silently fall through to
+	// Protocol_Custom, where it would collide with the local transport.
+	test := parseTestCase{
+		raw:  "file:///path",
+		fail: true,
+	}
+	test.run(t)
+}
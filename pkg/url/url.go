@@ -0,0 +1,65 @@
+package url
+
+import (
+	"strings"
+)
+
+// Protocol specifies the type of endpoint a URL targets, and thus which
+// Transport should be used to connect to it.
+type Protocol uint8
+
+const (
+	// Protocol_Local indicates a local filesystem path.
+	Protocol_Local Protocol = iota
+	// Protocol_SSH indicates an SSH endpoint, given either SCP-style or as
+	// an ssh:// URL.
+	Protocol_SSH
+	// Protocol_HTTPS indicates an HTTP/2-tunneled HTTPS endpoint.
+	Protocol_HTTPS
+	// Protocol_Custom indicates a scheme-qualified endpoint handled by a
+	// transport registered via session.RegisterTransport; the raw URL is
+	// preserved opaquely in Path for that transport to interpret.
+	Protocol_Custom
+)
+
+// URL represents a parsed Mutagen endpoint specification.
+type URL struct {
+	// Protocol indicates the type of endpoint this URL targets.
+	Protocol Protocol
+	// Username is the username specified in the URL, if any.
+	Username string
+	// Hostname is the hostname specified in the URL, for non-local
+	// protocols.
+	Hostname string
+	// Port is the port specified in the URL, or 0 if none was specified.
+	Port uint32
+	// Path is the path component of the URL. For Protocol_Custom, it holds
+	// the entire raw URL, which the registered transport is responsible for
+	// interpreting.
+	Path string
+}
+
+// Scheme returns the scheme string that identifies the transport registered
+// to handle this URL (see the transport registry in the session package).
+// For the built-in protocols this is simply the canonical scheme name; for
+// Protocol_Custom it's extracted from the scheme-qualified raw URL preserved
+// in Path, so that e.g. "docker://" and "k8s://" URLs resolve to distinct
+// registry keys rather than colliding on a single shared Protocol_Custom
+// value.
+func (u *URL) Scheme() string {
+	switch u.Protocol {
+	case Protocol_Local:
+		return "file"
+	case Protocol_SSH:
+		return "ssh"
+	case Protocol_HTTPS:
+		return "https"
+	case Protocol_Custom:
+		if match := schemeURLRegex.FindStringSubmatch(u.Path); match != nil {
+			return strings.ToLower(match[1])
+		}
+		return ""
+	default:
+		return ""
+	}
+}
@@ -5,7 +5,6 @@ import (
 
 	"github.com/pkg/errors"
 
-	"github.com/havoc-io/mutagen/agent"
 	urlpkg "github.com/havoc-io/mutagen/url"
 )
 
@@ -17,35 +16,23 @@ func connect(
 	alpha bool,
 	prompter string,
 ) (endpoint, error) {
-	// Handle based on protocol.
-	if url.Protocol == urlpkg.Protocol_Local {
-		// Create a local endpoint.
-		endpoint, err := newLocalEndpoint(session, version, url.Path, ignores, alpha)
-		if err != nil {
-			return nil, errors.Wrap(err, "unable to create local endpoint")
-		}
-
-		// Success.
-		return endpoint, nil
-	} else if url.Protocol == urlpkg.Protocol_SSH {
-		// Dial using the agent package, watching for errors
-		connection, err := agent.DialSSH(url, prompter, agent.ModeEndpoint)
-		if err != nil {
-			return nil, errors.Wrap(err, "unable to connect to SSH remote")
-		}
-
-		// Create a remote endpoint.
-		endpoint, err := newRemoteEndpoint(connection, session, version, url.Path, ignores, alpha)
-		if err != nil {
-			return nil, errors.Wrap(err, "unable to create remote endpoint")
-		}
+	// Look up the transport registered for this URL's scheme. This covers
+	// not only the built-in local, SSH, and HTTPS schemes, but also any
+	// scheme a third-party package has registered for a Protocol_Custom URL.
+	scheme := url.Scheme()
+	transport, ok := transports[scheme]
+	if !ok {
+		return nil, errors.Errorf("unknown protocol or scheme: %s", scheme)
+	}
 
-		// Success.
-		return endpoint, nil
-	} else {
-		// Handle unknown protocols.
-		return nil, errors.Errorf("unknown protocol: %s", url.Protocol)
+	// Dial using the transport.
+	endpoint, err := transport.connect(context.Background(), url, prompter, session, version, ignores, alpha)
+	if err != nil {
+		return nil, err
 	}
+
+	// Success.
+	return endpoint, nil
 }
 
 type connectResult struct {
@@ -63,13 +50,20 @@ func reconnect(ctx context.Context,
 	ignores IgnoreSpecification,
 	alpha bool,
 ) (endpoint, error) {
+	// Look up the transport for this URL's scheme.
+	scheme := url.Scheme()
+	transport, ok := transports[scheme]
+	if !ok {
+		return nil, errors.Errorf("unknown protocol or scheme: %s", scheme)
+	}
+
 	// Create a channel to deliver the connection result.
 	results := make(chan connectResult)
 
 	// Start a connection operation in the background.
 	go func() {
 		// Perform the connection.
-		endpoint, err := connect(session, version, url, ignores, alpha, "")
+		endpoint, err := transport.connect(ctx, url, "", session, version, ignores, alpha)
 
 		// If we can't transmit the resulting endpoint, close it.
 		select {
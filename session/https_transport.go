@@ -0,0 +1,73 @@
+package session
+
+import (
+	"context"
+	"crypto/tls"
+	"os"
+
+	"github.com/pkg/errors"
+
+	httpspkg "github.com/havoc-io/mutagen/https"
+	urlpkg "github.com/havoc-io/mutagen/url"
+)
+
+// httpsBearerTokenEnvironmentVariable, if set, is sent as a bearer token
+// when dialing Protocol_HTTPS endpoints. Deployments that authenticate via
+// mTLS instead can leave this unset.
+const httpsBearerTokenEnvironmentVariable = "MUTAGEN_HTTPS_BEARER_TOKEN"
+
+// httpsClientCertificateEnvironmentVariable, httpsClientKeyEnvironmentVariable,
+// and httpsCACertificateEnvironmentVariable, if all set, name the client
+// certificate/key pair and CA certificate used to authenticate via mTLS when
+// dialing Protocol_HTTPS endpoints. Deployments that authenticate via a
+// bearer token instead can leave these unset.
+const (
+	httpsClientCertificateEnvironmentVariable = "MUTAGEN_HTTPS_CLIENT_CERTIFICATE"
+	httpsClientKeyEnvironmentVariable         = "MUTAGEN_HTTPS_CLIENT_KEY"
+	httpsCACertificateEnvironmentVariable     = "MUTAGEN_HTTPS_CA_CERTIFICATE"
+)
+
+func init() {
+	RegisterTransport("https", httpsTransport{})
+}
+
+// httpsTransport is the Transport implementation registered for the
+// https:// protocol. It tunnels the endpoint stream over HTTP/2 to a
+// Mutagen agent HTTP server, for deployments that can't expose SSH but can
+// expose HTTPS.
+type httpsTransport struct{}
+
+func (httpsTransport) connect(
+	ctx context.Context,
+	url *urlpkg.URL,
+	_ string,
+	session string,
+	version Version,
+	ignores IgnoreSpecification,
+	alpha bool,
+) (endpoint, error) {
+	tlsConfig := &tls.Config{}
+	certPath := os.Getenv(httpsClientCertificateEnvironmentVariable)
+	keyPath := os.Getenv(httpsClientKeyEnvironmentVariable)
+	caCertPath := os.Getenv(httpsCACertificateEnvironmentVariable)
+	if certPath != "" && keyPath != "" && caCertPath != "" {
+		loaded, err := httpspkg.LoadClientTLSConfig(certPath, keyPath, caCertPath)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to load mTLS client configuration")
+		}
+		tlsConfig = loaded
+	}
+
+	connection, err := httpspkg.Dial(ctx, url, os.Getenv(httpsBearerTokenEnvironmentVariable), tlsConfig)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to dial HTTPS remote")
+	}
+
+	endpoint, err := newRemoteEndpoint(connection, session, version, url.Path, ignores, alpha)
+	if err != nil {
+		connection.Close()
+		return nil, errors.Wrap(err, "unable to create remote endpoint")
+	}
+
+	return endpoint, nil
+}
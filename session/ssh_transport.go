@@ -0,0 +1,126 @@
+package session
+
+import (
+	"context"
+	"os"
+
+	"github.com/pkg/errors"
+
+	"github.com/havoc-io/mutagen/agent"
+	sshpkg "github.com/havoc-io/mutagen/ssh"
+	urlpkg "github.com/havoc-io/mutagen/url"
+)
+
+// sshLegacyEnvironmentVariable, when set to a non-empty value, forces
+// connect to fall back to the legacy ssh-binary-based transport
+// (agent.DialSSH) instead of dialing natively via golang.org/x/crypto/ssh.
+// This exists as an escape hatch while the native transport matures.
+const sshLegacyEnvironmentVariable = "MUTAGEN_SSH_LEGACY"
+
+// remoteAgentCommand is the command invoked on the remote to start the
+// Mutagen agent in endpoint mode, assuming it's already installed and on
+// PATH.
+const remoteAgentCommand = "mutagen-agent endpoint"
+
+// remoteAgentInstallPath is the path (resolved relative to the remote
+// user's home directory) at which installRemoteAgent installs the agent
+// binary if it isn't already reachable via remoteAgentCommand.
+const remoteAgentInstallPath = ".mutagen-agent"
+
+// remoteAgentInstalledCommand is the command used to invoke an agent
+// previously installed at remoteAgentInstallPath.
+const remoteAgentInstalledCommand = "./" + remoteAgentInstallPath + " endpoint"
+
+// localAgentBinaryEnvironmentVariable names a local agent binary (built for
+// the remote's platform) to install via installRemoteAgent if the remote
+// doesn't already have mutagen-agent on its PATH. If unset, no install is
+// attempted and a missing remote agent simply fails connect.
+const localAgentBinaryEnvironmentVariable = "MUTAGEN_AGENT_BINARY"
+
+func init() {
+	RegisterTransport("ssh", sshTransport{})
+}
+
+// sshTransport is the Transport implementation registered for the ssh://
+// protocol. It dials natively via golang.org/x/crypto/ssh, which avoids the
+// requirement for an ssh binary on the client and allows reconnect to
+// cancel an in-flight dial at the socket level. If
+// MUTAGEN_SSH_LEGACY is set, it falls back to shelling out to the system
+// ssh client via agent.DialSSH.
+type sshTransport struct{}
+
+func (sshTransport) connect(
+	ctx context.Context,
+	url *urlpkg.URL,
+	prompter string,
+	session string,
+	version Version,
+	ignores IgnoreSpecification,
+	alpha bool,
+) (endpoint, error) {
+	if os.Getenv(sshLegacyEnvironmentVariable) != "" {
+		return legacySSHTransport{}.connect(ctx, url, prompter, session, version, ignores, alpha)
+	}
+
+	client, err := sshpkg.Dial(ctx, url, prompter)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to dial SSH remote")
+	}
+
+	connection, err := client.Connect(remoteAgentCommand)
+	if err != nil {
+		// The remote may simply not have mutagen-agent on its PATH yet. If
+		// we have a local agent binary to offer, copy it over (multiplexed
+		// onto the same connection via client.InstallAgent) and retry
+		// rather than failing outright.
+		localAgentBinary := os.Getenv(localAgentBinaryEnvironmentVariable)
+		if localAgentBinary == "" {
+			client.Close()
+			return nil, errors.Wrap(err, "unable to start remote agent")
+		}
+		if installErr := client.InstallAgent(localAgentBinary, remoteAgentInstallPath); installErr != nil {
+			client.Close()
+			return nil, errors.Wrap(installErr, "unable to install remote agent")
+		}
+		if connection, err = client.Connect(remoteAgentInstalledCommand); err != nil {
+			client.Close()
+			return nil, errors.Wrap(err, "unable to start installed remote agent")
+		}
+	}
+
+	endpoint, err := newRemoteEndpoint(connection, session, version, url.Path, ignores, alpha)
+	if err != nil {
+		connection.Close()
+		client.Close()
+		return nil, errors.Wrap(err, "unable to create remote endpoint")
+	}
+
+	return endpoint, nil
+}
+
+// legacySSHTransport is the original Transport implementation, which shells
+// out to the system ssh client via agent.DialSSH. It remains available as a
+// fallback via MUTAGEN_SSH_LEGACY while the native transport matures.
+type legacySSHTransport struct{}
+
+func (legacySSHTransport) connect(
+	_ context.Context,
+	url *urlpkg.URL,
+	prompter string,
+	session string,
+	version Version,
+	ignores IgnoreSpecification,
+	alpha bool,
+) (endpoint, error) {
+	connection, err := agent.DialSSH(url, prompter, agent.ModeEndpoint)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to connect to SSH remote")
+	}
+
+	endpoint, err := newRemoteEndpoint(connection, session, version, url.Path, ignores, alpha)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to create remote endpoint")
+	}
+
+	return endpoint, nil
+}
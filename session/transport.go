@@ -0,0 +1,74 @@
+package session
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	urlpkg "github.com/havoc-io/mutagen/url"
+)
+
+// Transport represents a mechanism capable of dialing a remote endpoint for
+// a particular URL scheme, registered via RegisterTransport. Because connect
+// is unexported and returns the unexported endpoint type, only types declared
+// in the session package can implement this interface; packages outside of
+// session cannot supply their own transports.
+type Transport interface {
+	// connect dials the endpoint described by url and constructs the
+	// resulting endpoint. prompter, if non-empty, identifies a prompter that
+	// may be used to request credentials or confirmation from the user. ctx
+	// may be used to cancel an in-flight connection attempt.
+	connect(
+		ctx context.Context,
+		url *urlpkg.URL,
+		prompter string,
+		session string,
+		version Version,
+		ignores IgnoreSpecification,
+		alpha bool,
+	) (endpoint, error)
+}
+
+// transports is the registry of transports, keyed by URL scheme (see
+// urlpkg.URL.Scheme), mirroring go-git's plumbing/transport/client registry.
+// Keying by scheme rather than by urlpkg.Protocol gives each custom scheme
+// (e.g. "docker", "k8s") its own entry without a dedicated Protocol_*
+// constant, since every such scheme parses to the shared Protocol_Custom
+// value and is disambiguated only by its scheme string.
+var transports = make(map[string]Transport)
+
+// RegisterTransport registers a transport implementation for the specified
+// URL scheme (e.g. "ssh", "https", or a custom scheme such as "docker").
+// It panics if a transport has already been registered for that scheme,
+// since this indicates a programming error (e.g. two registrations attempting
+// to claim the same scheme).
+func RegisterTransport(scheme string, transport Transport) {
+	if _, ok := transports[scheme]; ok {
+		panic("transport already registered for scheme: " + scheme)
+	}
+	transports[scheme] = transport
+}
+
+func init() {
+	RegisterTransport("file", localTransport{})
+}
+
+// localTransport is the built-in Transport implementation for local
+// filesystem endpoints.
+type localTransport struct{}
+
+func (localTransport) connect(
+	_ context.Context,
+	url *urlpkg.URL,
+	_ string,
+	session string,
+	version Version,
+	ignores IgnoreSpecification,
+	alpha bool,
+) (endpoint, error) {
+	endpoint, err := newLocalEndpoint(session, version, url.Path, ignores, alpha)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to create local endpoint")
+	}
+	return endpoint, nil
+}
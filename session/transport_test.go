@@ -0,0 +1,62 @@
+package session
+
+import (
+	"context"
+	"testing"
+
+	urlpkg "github.com/havoc-io/mutagen/url"
+)
+
+// fakeTransport is a minimal Transport used to exercise registry dispatch
+// without depending on a real endpoint implementation.
+type fakeTransport struct {
+	called bool
+}
+
+func (f *fakeTransport) connect(
+	_ context.Context,
+	_ *urlpkg.URL,
+	_ string,
+	_ string,
+	_ Version,
+	_ IgnoreSpecification,
+	_ bool,
+) (endpoint, error) {
+	f.called = true
+	return nil, nil
+}
+
+func TestRegisterTransportDispatchesByScheme(t *testing.T) {
+	fake := &fakeTransport{}
+	RegisterTransport("mutagentest-dispatch", fake)
+
+	var version Version
+	var ignores IgnoreSpecification
+	url := &urlpkg.URL{Protocol: urlpkg.Protocol_Custom, Path: "mutagentest-dispatch://host/path"}
+	if _, err := connect("session", version, url, ignores, true, ""); err != nil {
+		t.Fatal("connect failed:", err)
+	}
+	if !fake.called {
+		t.Fatal("connect did not dispatch to the transport registered for the URL's scheme")
+	}
+}
+
+func TestRegisterTransportUnknownSchemeFails(t *testing.T) {
+	var version Version
+	var ignores IgnoreSpecification
+	url := &urlpkg.URL{Protocol: urlpkg.Protocol_Custom, Path: "mutagentest-unregistered://host/path"}
+	if _, err := connect("session", version, url, ignores, true, ""); err == nil {
+		t.Fatal("connect succeeded for a scheme with no registered transport")
+	}
+}
+
+func TestRegisterTransportPanicsOnDuplicateScheme(t *testing.T) {
+	RegisterTransport("mutagentest-duplicate", &fakeTransport{})
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("RegisterTransport did not panic on duplicate scheme registration")
+		}
+	}()
+	RegisterTransport("mutagentest-duplicate", &fakeTransport{})
+}
@@ -0,0 +1,24 @@
+package ssh
+
+import (
+	"net"
+
+	sshagent "golang.org/x/crypto/ssh/agent"
+)
+
+// dialAgent connects to the local ssh-agent, returning a client usable both
+// for public-key authentication (see agentAuth) and for forwarding to a
+// remote host (see Client.ForwardAgent), along with the underlying
+// connection. The agent.Agent interface exposes no Close method, so the raw
+// conn must be retained and closed by the caller (see Client.agentConn) once
+// the agent is no longer needed; otherwise the socket/pipe to the local
+// ssh-agent leaks for the life of the process. It returns an error if no
+// agent is reachable, which callers should treat as agent-based auth simply
+// being unavailable rather than as a fatal condition.
+func dialAgent() (sshagent.Agent, net.Conn, error) {
+	conn, err := dialAgentSocket()
+	if err != nil {
+		return nil, nil, err
+	}
+	return sshagent.NewClient(conn), conn, nil
+}
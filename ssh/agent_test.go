@@ -0,0 +1,90 @@
+package ssh
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"net"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+	sshagent "golang.org/x/crypto/ssh/agent"
+)
+
+// TestAgentAuthSignAndList exercises the agentAuth code path against an
+// in-memory agent.NewKeyring() server, rather than a real ssh-agent, by
+// connecting a client and server over a net.Pipe.
+func TestAgentAuthSignAndList(t *testing.T) {
+	// Create an in-memory keyring and add a key to it.
+	keyring := sshagent.NewKeyring()
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal("unable to generate key:", err)
+	}
+	if err := keyring.Add(sshagent.AddedKey{PrivateKey: private}); err != nil {
+		t.Fatal("unable to add key to keyring:", err)
+	}
+
+	// Connect a client to the keyring over an in-memory pipe, simulating the
+	// SSH_AUTH_SOCK connection that dialAgentSocket would normally provide.
+	clientConn, serverConn := net.Pipe()
+	defer clientConn.Close()
+	go sshagent.ServeAgent(keyring, serverConn)
+
+	client := sshagent.NewClient(clientConn)
+	if _, err := client.List(); err != nil {
+		t.Fatal("unable to list agent keys:", err)
+	}
+
+	method, err := (agentAuth{client: client}).authMethod()
+	if err != nil {
+		t.Fatal("agentAuth.authMethod failed:", err)
+	}
+	if method == nil {
+		t.Fatal("agentAuth.authMethod returned nil method")
+	}
+
+	// Drive the signers backing method (ssh.PublicKeysCallback(client.Signers))
+	// through an actual signature, so a regression in the agent's sign path
+	// (as opposed to just its list path) would fail this test.
+	signers, err := client.Signers()
+	if err != nil {
+		t.Fatal("unable to retrieve agent signers:", err)
+	}
+	if len(signers) != 1 {
+		t.Fatalf("unexpected signer count: got %d, wanted 1", len(signers))
+	}
+
+	challenge := []byte("agentAuth sign/list round trip")
+	signature, err := signers[0].Sign(rand.Reader, challenge)
+	if err != nil {
+		t.Fatal("unable to sign challenge via agent:", err)
+	}
+	if err := signers[0].PublicKey().Verify(challenge, signature); err != nil {
+		t.Fatal("agent signature failed to verify:", err)
+	}
+
+	// Confirm it's actually the key we added to the keyring, not some other
+	// signer the client happened to produce.
+	if !bytes.Equal(signers[0].PublicKey().Marshal(), mustPublicKey(t, keyring).Marshal()) {
+		t.Fatal("agent signer does not match the key added to the keyring")
+	}
+}
+
+// mustPublicKey returns the ssh.PublicKey for the single key held by keyring,
+// failing the test if the keyring doesn't contain exactly one key.
+func mustPublicKey(t *testing.T, keyring sshagent.Agent) ssh.PublicKey {
+	t.Helper()
+	keys, err := keyring.List()
+	if err != nil {
+		t.Fatal("unable to list keyring keys:", err)
+	}
+	if len(keys) != 1 {
+		t.Fatalf("unexpected keyring key count: got %d, wanted 1", len(keys))
+	}
+	key, err := ssh.ParsePublicKey(keys[0].Blob)
+	if err != nil {
+		t.Fatal("unable to parse keyring public key:", err)
+	}
+	return key
+}
@@ -0,0 +1,20 @@
+// +build !windows
+
+package ssh
+
+import (
+	"net"
+	"os"
+
+	"github.com/pkg/errors"
+)
+
+// dialAgentSocket dials the local ssh-agent via the socket referenced by the
+// SSH_AUTH_SOCK environment variable.
+func dialAgentSocket() (net.Conn, error) {
+	socket := os.Getenv("SSH_AUTH_SOCK")
+	if socket == "" {
+		return nil, errors.New("SSH_AUTH_SOCK not set")
+	}
+	return net.Dial("unix", socket)
+}
@@ -0,0 +1,17 @@
+// +build windows
+
+package ssh
+
+import (
+	"net"
+
+	"github.com/Microsoft/go-winio"
+)
+
+// opensshAgentPipe is the named pipe exposed by Win32-OpenSSH's agent.
+const opensshAgentPipe = `\\.\pipe\openssh-ssh-agent`
+
+// dialAgentSocket dials the local ssh-agent via its named pipe.
+func dialAgentSocket() (net.Conn, error) {
+	return winio.DialPipe(opensshAgentPipe, nil)
+}
@@ -0,0 +1,169 @@
+package ssh
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/ssh"
+	sshagent "golang.org/x/crypto/ssh/agent"
+
+	"github.com/havoc-io/mutagen/prompt"
+	urlpkg "github.com/havoc-io/mutagen/url"
+)
+
+// AuthMethod represents a mechanism for authenticating an SSH session. It
+// allows auth schemes (ssh-agent, on-disk keys, password, keyboard-
+// interactive) to be composed in clientConfig without that function needing
+// to know the details of each.
+type AuthMethod interface {
+	// authMethod returns the underlying ssh.AuthMethod, or an error if this
+	// method isn't usable (e.g. no agent is running, or no key file could be
+	// read). A method being unusable is not fatal: clientConfig simply
+	// excludes it and tries the next one.
+	authMethod() (ssh.AuthMethod, error)
+}
+
+// defaultIdentityFiles lists the private key files that are tried, in
+// order, when no explicit identity is configured, mirroring OpenSSH's own
+// defaults.
+var defaultIdentityFiles = []string{
+	"id_rsa",
+	"id_ed25519",
+	"id_ecdsa",
+}
+
+// agentAuth is an AuthMethod that authenticates using keys held by a
+// running ssh-agent, reached via SSH_AUTH_SOCK on POSIX systems or the
+// Win32-OpenSSH named pipe on Windows.
+type agentAuth struct {
+	client sshagent.Agent
+}
+
+func (a agentAuth) authMethod() (ssh.AuthMethod, error) {
+	return ssh.PublicKeysCallback(a.client.Signers), nil
+}
+
+// keyFileAuth is an AuthMethod that authenticates using a private key read
+// from disk.
+type keyFileAuth struct {
+	path string
+}
+
+func (k keyFileAuth) authMethod() (ssh.AuthMethod, error) {
+	contents, err := ioutil.ReadFile(k.path)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to read key file")
+	}
+	signer, err := ssh.ParsePrivateKey(contents)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to parse key file")
+	}
+	return ssh.PublicKeys(signer), nil
+}
+
+// passwordAuth is an AuthMethod that requests a password from a prompter.
+type passwordAuth struct {
+	prompter string
+}
+
+func (p passwordAuth) authMethod() (ssh.AuthMethod, error) {
+	if p.prompter == "" {
+		return nil, errors.New("password authentication requires a prompter")
+	}
+	return ssh.PasswordCallback(func() (string, error) {
+		return prompt.SecretPrompt(p.prompter, "Password: ")
+	}), nil
+}
+
+// keyboardInteractiveAuth is an AuthMethod that relays keyboard-interactive
+// challenges to a prompter.
+type keyboardInteractiveAuth struct {
+	prompter string
+}
+
+func (k keyboardInteractiveAuth) authMethod() (ssh.AuthMethod, error) {
+	if k.prompter == "" {
+		return nil, errors.New("keyboard-interactive authentication requires a prompter")
+	}
+	return ssh.KeyboardInteractive(func(name, instruction string, questions []string, echos []bool) ([]string, error) {
+		answers := make([]string, len(questions))
+		for i, question := range questions {
+			var answer string
+			var err error
+			if i < len(echos) && echos[i] {
+				answer, err = prompt.Prompt(k.prompter, question)
+			} else {
+				answer, err = prompt.SecretPrompt(k.prompter, question)
+			}
+			if err != nil {
+				return nil, err
+			}
+			answers[i] = answer
+		}
+		return answers, nil
+	}), nil
+}
+
+// defaultAuthMethods returns the AuthMethods to try, in order of
+// preference: ssh-agent (if reachable), the default on-disk identity files,
+// password, and finally keyboard-interactive. If an agent was reachable, it
+// is also returned (along with its underlying connection, which the caller
+// owns and must close) so that the caller can hold onto it for forwarding.
+func defaultAuthMethods(prompter string) ([]AuthMethod, sshagent.Agent, net.Conn) {
+	var methods []AuthMethod
+	var client sshagent.Agent
+	var conn net.Conn
+
+	if a, c, err := dialAgent(); err == nil {
+		client = a
+		conn = c
+		methods = append(methods, agentAuth{a})
+	}
+
+	if home, err := os.UserHomeDir(); err == nil {
+		for _, name := range defaultIdentityFiles {
+			path := filepath.Join(home, ".ssh", name)
+			if _, err := os.Stat(path); err == nil {
+				methods = append(methods, keyFileAuth{path})
+			}
+		}
+	}
+
+	methods = append(methods, passwordAuth{prompter}, keyboardInteractiveAuth{prompter})
+	return methods, client, conn
+}
+
+// clientConfig constructs the ssh.ClientConfig used to dial url, composing
+// the available AuthMethods (in order of preference) and verifying the
+// remote host key against the user's known_hosts file. It also returns the
+// ssh-agent client used for authentication (if any) and its underlying
+// connection, which the caller takes ownership of and must close once the
+// agent is no longer needed.
+func clientConfig(url *urlpkg.URL, prompter string) (*ssh.ClientConfig, sshagent.Agent, net.Conn, error) {
+	hostKeyCallback, err := knownHostsCallback(prompter)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	candidates, agentClient, agentConn := defaultAuthMethods(prompter)
+
+	var methods []ssh.AuthMethod
+	for _, candidate := range candidates {
+		method, err := candidate.authMethod()
+		if err != nil {
+			continue
+		}
+		methods = append(methods, method)
+	}
+
+	config := &ssh.ClientConfig{
+		User:            url.Username,
+		Auth:            methods,
+		HostKeyCallback: hostKeyCallback,
+		Timeout:         connectTimeout,
+	}
+	return config, agentClient, agentConn, nil
+}
@@ -0,0 +1,200 @@
+// Package ssh implements a native Go SSH transport for dialing Mutagen
+// endpoints, built on top of golang.org/x/crypto/ssh. It exists so that
+// session.connect no longer needs to shell out to a system ssh binary (see
+// agent.DialSSH) in order to reach remote endpoints.
+package ssh
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/ssh"
+	sshagent "golang.org/x/crypto/ssh/agent"
+
+	urlpkg "github.com/havoc-io/mutagen/url"
+)
+
+const (
+	// connectTimeout is the maximum amount of time to wait for the initial
+	// TCP dial and SSH handshake to complete.
+	connectTimeout = 10 * time.Second
+
+	// defaultPort is the port used when a URL doesn't specify one.
+	defaultPort = 22
+)
+
+// Client wraps an *ssh.Client and multiplexes the channels that Mutagen
+// needs over a single underlying TCP connection: the channel used to copy
+// the agent binary to the remote, the channel used to invoke the agent, and
+// the channel used for the resulting endpoint stream.
+type Client struct {
+	client *ssh.Client
+	// agent is the local ssh-agent client used to authenticate this
+	// connection, if one was reachable when it was established. It is held
+	// for the life of the Client so that it can be forwarded to the remote
+	// via ForwardAgent.
+	agent sshagent.Agent
+	// agentConn is the underlying connection backing agent. The agent.Agent
+	// interface has no Close method, so this is what actually gets closed
+	// (by Close) to release the local ssh-agent socket/pipe; it's nil if no
+	// agent was reachable.
+	agentConn net.Conn
+}
+
+// Dial establishes a connection to the SSH server described by url, using
+// prompter to satisfy any interactive authentication prompts, and returns a
+// Client multiplexed over the resulting connection. ctx may be used to
+// cancel the dial and handshake; unlike the agent.DialSSH shell-out, this
+// cancellation is respected at the socket level rather than merely being
+// abandoned in a background goroutine.
+func Dial(ctx context.Context, url *urlpkg.URL, prompter string) (*Client, error) {
+	config, agentClient, agentConn, err := clientConfig(url, prompter)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to construct SSH client configuration")
+	}
+	// Unless and until we return a Client that takes ownership of agentConn,
+	// any early return below must close it itself to avoid leaking the
+	// local ssh-agent socket/pipe.
+	closeAgentConn := func() {
+		if agentConn != nil {
+			agentConn.Close()
+		}
+	}
+
+	address := url.Hostname
+	if url.Port != 0 {
+		address = fmt.Sprintf("%s:%d", address, url.Port)
+	} else {
+		address = fmt.Sprintf("%s:%d", address, defaultPort)
+	}
+
+	dialer := &net.Dialer{Timeout: connectTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		closeAgentConn()
+		return nil, errors.Wrap(err, "unable to dial SSH server")
+	}
+
+	// There's no context-aware variant of ssh.NewClientConn, so perform the
+	// handshake in the background and watch for context cancellation
+	// ourselves, closing the underlying connection (which unblocks the
+	// handshake) if the context is cancelled first.
+	type handshakeResult struct {
+		conn  ssh.Conn
+		chans <-chan ssh.NewChannel
+		reqs  <-chan *ssh.Request
+		err   error
+	}
+	results := make(chan handshakeResult, 1)
+	go func() {
+		conn, chans, reqs, err := ssh.NewClientConn(conn, address, config)
+		results <- handshakeResult{conn, chans, reqs, err}
+	}()
+
+	select {
+	case <-ctx.Done():
+		conn.Close()
+		closeAgentConn()
+		return nil, errors.Wrap(ctx.Err(), "SSH handshake cancelled")
+	case result := <-results:
+		if result.err != nil {
+			closeAgentConn()
+			return nil, errors.Wrap(result.err, "SSH handshake failed")
+		}
+		return &Client{
+			client:    ssh.NewClient(result.conn, result.chans, result.reqs),
+			agent:     agentClient,
+			agentConn: agentConn,
+		}, nil
+	}
+}
+
+// ForwardAgent requests ssh-agent forwarding on session and serves the
+// local agent's protocol over the resulting channel, so that a Mutagen
+// agent spawned on the remote can use it to authenticate further SSH hops.
+// It is a no-op if no local agent was available when the connection was
+// established.
+func (c *Client) ForwardAgent(session *ssh.Session) error {
+	if c.agent == nil {
+		return nil
+	}
+	if err := sshagent.RequestAgentForwarding(session); err != nil {
+		return errors.Wrap(err, "unable to request agent forwarding")
+	}
+	return sshagent.ForwardToAgent(c.client, c.agent)
+}
+
+// Open starts a new session multiplexed over the underlying SSH connection,
+// suitable for copying the agent binary or invoking it on the remote.
+func (c *Client) Open() (*ssh.Session, error) {
+	return c.client.NewSession()
+}
+
+// Close closes the underlying SSH connection and all sessions multiplexed
+// over it, along with the connection to the local ssh-agent (if one was
+// used to authenticate).
+func (c *Client) Close() error {
+	err := c.client.Close()
+	if c.agentConn != nil {
+		if agentErr := c.agentConn.Close(); err == nil {
+			err = agentErr
+		}
+	}
+	return err
+}
+
+// sessionStream adapts an *ssh.Session's standard input/output pipes into a
+// single io.ReadWriteCloser representing the endpoint stream, closing the
+// session (and thus the remote process) when the stream is closed.
+type sessionStream struct {
+	io.Reader
+	io.WriteCloser
+	session *ssh.Session
+}
+
+func (s *sessionStream) Close() error {
+	writeErr := s.WriteCloser.Close()
+	waitErr := s.session.Wait()
+	if writeErr != nil {
+		return writeErr
+	}
+	return waitErr
+}
+
+// Connect starts the specified command (normally an invocation of the
+// remote Mutagen agent) in a new session and returns a stream connected to
+// its standard input/output.
+func (c *Client) Connect(command string) (io.ReadWriteCloser, error) {
+	session, err := c.Open()
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to open SSH session")
+	}
+
+	stdin, err := session.StdinPipe()
+	if err != nil {
+		session.Close()
+		return nil, errors.Wrap(err, "unable to redirect session input")
+	}
+
+	stdout, err := session.StdoutPipe()
+	if err != nil {
+		session.Close()
+		return nil, errors.Wrap(err, "unable to redirect session output")
+	}
+
+	// Forward our agent (if any) so that the remote Mutagen agent can use it
+	// for further SSH hops. This is best-effort: a forwarding failure
+	// shouldn't prevent the endpoint connection from being established.
+	c.ForwardAgent(session)
+
+	if err := session.Start(command); err != nil {
+		session.Close()
+		return nil, errors.Wrap(err, "unable to start remote command")
+	}
+
+	return &sessionStream{Reader: stdout, WriteCloser: stdin, session: session}, nil
+}
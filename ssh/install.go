@@ -0,0 +1,62 @@
+package ssh
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/pkg/errors"
+)
+
+// InstallAgent copies the agent binary at localPath to remotePath (resolved
+// relative to the remote user's home directory by the remote shell) over a
+// new session multiplexed onto the same underlying SSH connection used for
+// the main endpoint stream, then marks it executable. It's invoked by
+// sshTransport.connect as a fallback when the remote doesn't already have
+// mutagen-agent on its PATH.
+func (c *Client) InstallAgent(localPath, remotePath string) error {
+	local, err := os.Open(localPath)
+	if err != nil {
+		return errors.Wrap(err, "unable to open local agent binary")
+	}
+	defer local.Close()
+
+	session, err := c.Open()
+	if err != nil {
+		return errors.Wrap(err, "unable to open SSH session")
+	}
+	defer session.Close()
+
+	stdin, err := session.StdinPipe()
+	if err != nil {
+		return errors.Wrap(err, "unable to redirect session input")
+	}
+
+	// Forward our agent (if any), since the remote may need to reach a
+	// further SSH hop (e.g. a private package mirror) while installing.
+	c.ForwardAgent(session)
+
+	// Stream the binary to remotePath and mark it executable in a single
+	// remote shell invocation, reading the contents from our stdin pipe so
+	// the whole transfer runs over this one multiplexed channel.
+	command := fmt.Sprintf("sh -c 'cat > %s && chmod 755 %s'", remotePath, remotePath)
+	if err := session.Start(command); err != nil {
+		return errors.Wrap(err, "unable to start remote install command")
+	}
+
+	if _, err := io.Copy(stdin, local); err != nil {
+		stdin.Close()
+		session.Wait()
+		return errors.Wrap(err, "unable to copy agent binary to remote")
+	}
+	if err := stdin.Close(); err != nil {
+		session.Wait()
+		return errors.Wrap(err, "unable to close remote install input")
+	}
+
+	if err := session.Wait(); err != nil {
+		return errors.Wrap(err, "remote install command failed")
+	}
+
+	return nil
+}
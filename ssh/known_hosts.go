@@ -0,0 +1,92 @@
+package ssh
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+
+	"github.com/havoc-io/mutagen/prompt"
+)
+
+// knownHostsCallback constructs a host key callback backed by the user's
+// known_hosts file, mirroring the verification OpenSSH performs by default.
+// If a host's key isn't yet recorded there (including the case where the
+// file doesn't exist at all), it falls back to the same trust-on-first-use
+// behavior the system ssh client offers interactively: prompter is asked to
+// confirm the key's fingerprint, and on acceptance the entry is appended to
+// known_hosts so that subsequent connections verify silently. A key that IS
+// present but doesn't match (indicating a potential man-in-the-middle) is
+// never trusted automatically, regardless of prompter.
+func knownHostsCallback(prompter string) (ssh.HostKeyCallback, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to determine home directory")
+	}
+
+	sshDir := filepath.Join(home, ".ssh")
+	if err := os.MkdirAll(sshDir, 0700); err != nil {
+		return nil, errors.Wrap(err, "unable to create .ssh directory")
+	}
+
+	path := filepath.Join(sshDir, "known_hosts")
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		file, createErr := os.OpenFile(path, os.O_CREATE|os.O_WRONLY, 0600)
+		if createErr != nil {
+			return nil, errors.Wrap(createErr, "unable to create known_hosts file")
+		}
+		file.Close()
+	}
+
+	callback, err := knownhosts.New(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to load known_hosts file")
+	}
+
+	return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		if err := callback(hostname, remote, key); err == nil {
+			return nil
+		} else if !knownhosts.IsHostUnknown(err) {
+			return err
+		}
+		return trustOnFirstUse(prompter, path, hostname, key)
+	}, nil
+}
+
+// trustOnFirstUse prompts prompter to accept an unrecognized host key,
+// mirroring OpenSSH's "authenticity of host ... can't be established"
+// prompt, and appends the key to known_hosts on acceptance so that later
+// connections to the same host verify without prompting again.
+func trustOnFirstUse(prompter, path, hostname string, key ssh.PublicKey) error {
+	if prompter == "" {
+		return errors.New("host key verification failed: host not in known_hosts and no prompter available")
+	}
+
+	question := fmt.Sprintf(
+		"The authenticity of host '%s' can't be established.\n%s key fingerprint is %s.\nAre you sure you want to continue connecting (yes/no)? ",
+		hostname, key.Type(), ssh.FingerprintSHA256(key),
+	)
+	answer, err := prompt.Prompt(prompter, question)
+	if err != nil {
+		return errors.Wrap(err, "unable to prompt for host key confirmation")
+	} else if answer != "yes" {
+		return errors.New("host key not accepted")
+	}
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return errors.Wrap(err, "unable to open known_hosts file")
+	}
+	defer file.Close()
+
+	writer := bufio.NewWriter(file)
+	if _, err := writer.WriteString(knownhosts.Line([]string{knownhosts.Normalize(hostname)}, key) + "\n"); err != nil {
+		return errors.Wrap(err, "unable to append to known_hosts file")
+	}
+	return writer.Flush()
+}
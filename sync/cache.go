@@ -0,0 +1,43 @@
+package sync
+
+import (
+	"time"
+)
+
+// Cache encodes the digests and chunk manifests computed for a tree of
+// files during a previous Scan, keyed by scan-relative path, so that a
+// subsequent Scan can skip re-hashing files whose metadata hasn't changed.
+type Cache struct {
+	// Entries maps each file's scan-relative path to its cached metadata.
+	Entries map[string]*CacheEntry
+}
+
+// GetEntries returns c.Entries, or nil if c is nil, allowing callers to
+// query a possibly-nil Cache without an explicit nil check.
+func (c *Cache) GetEntries() map[string]*CacheEntry {
+	if c == nil {
+		return nil
+	}
+	return c.Entries
+}
+
+// CacheEntry records the cached scan metadata for a single file: the
+// mode/modification time/size triple used to detect changes, the whole-file
+// digest computed the last time the file was actually hashed, and (since
+// the FastCDC chunker) the chunk manifest computed alongside that digest.
+type CacheEntry struct {
+	// Mode is the POSIX file mode recorded at scan time.
+	Mode uint32
+	// ModificationTime is the modification time recorded at scan time.
+	ModificationTime time.Time
+	// Size_ is the file size (in bytes) recorded at scan time.
+	Size_ uint64
+	// Digest is the whole-file digest computed the last time this file was
+	// hashed.
+	Digest []byte
+	// ChunkManifest is the content-defined chunk manifest computed alongside
+	// Digest. It's reused verbatim across scans as long as Mode,
+	// ModificationTime, and Size_ still match, so the differ can keep
+	// transmitting only chunks that have actually changed.
+	ChunkManifest []Chunk
+}
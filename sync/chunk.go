@@ -0,0 +1,117 @@
+package sync
+
+import (
+	"golang.org/x/crypto/blake2b"
+)
+
+const (
+	// chunkMinSize is the smallest chunk the cutpoint algorithm will ever
+	// emit (short of the final chunk in a file).
+	chunkMinSize = 2 * 1024
+
+	// chunkTargetSize is the size chunks are normalized toward. It's also
+	// the size used to derive chunkNormalizedMask/chunkReducedMask below.
+	chunkTargetSize = 8 * 1024
+
+	// chunkMaxSize is the largest chunk the cutpoint algorithm will ever
+	// emit; a cut is forced if no natural cutpoint is found before this
+	// many bytes have accumulated.
+	chunkMaxSize = 64 * 1024
+
+	// chunkNormalizedBits is chosen so that 2^chunkNormalizedBits ==
+	// chunkTargetSize; chunkReducedBits is two bits narrower, which (per
+	// FastCDC's normalized chunking) makes cutpoints roughly four times
+	// more likely once a chunk's running length has passed chunkTargetSize,
+	// pulling the chunk-size distribution in tighter around the target than
+	// a single fixed mask would.
+	chunkNormalizedBits = 13
+	chunkReducedBits    = chunkNormalizedBits - 2
+
+	chunkNormalizedMask = 1<<chunkNormalizedBits - 1
+	chunkReducedMask    = 1<<chunkReducedBits - 1
+)
+
+// Chunk describes a single content-defined chunk of a file, as produced by
+// chunker.
+type Chunk struct {
+	// Offset is the chunk's starting offset within the file.
+	Offset uint64
+	// Length is the number of bytes in the chunk.
+	Length uint64
+	// Digest is the BLAKE2b-256 digest of the chunk's contents.
+	Digest []byte
+}
+
+// chunker incrementally splits a stream of file data into content-defined
+// chunks using a FastCDC-style gear hash, so that the resulting chunk
+// boundaries are stable across insertions and renames elsewhere in the file
+// (unlike fixed-size blocks, which misalign on every subsequent byte once
+// content shifts). Feed it data via feed as it's read off disk; the zero
+// value is ready to use.
+type chunker struct {
+	// offset is the file offset of the start of pending.
+	offset uint64
+	// pending holds bytes that have been fed in but not yet attributed to a
+	// chunk. It never grows past chunkMaxSize plus the size of a single feed
+	// call, since feed drains it down below chunkMaxSize before returning.
+	pending []byte
+}
+
+// feed appends data to the chunker's pending bytes and extracts any chunks
+// that can now be determined, appending them to chunks and returning the
+// (possibly reallocated) result. If final is true (indicating end of
+// stream), all remaining pending bytes are flushed as a last, possibly
+// undersized, chunk; otherwise bytes are only extracted once a full
+// chunkMaxSize window is available to search for a cutpoint in.
+func (c *chunker) feed(data []byte, final bool, chunks []Chunk) []Chunk {
+	c.pending = append(c.pending, data...)
+
+	for len(c.pending) > 0 && (final || len(c.pending) >= chunkMaxSize) {
+		cut := findCutpoint(c.pending)
+		digest := blake2b.Sum256(c.pending[:cut])
+		chunks = append(chunks, Chunk{
+			Offset: c.offset,
+			Length: uint64(cut),
+			Digest: digest[:],
+		})
+		c.offset += uint64(cut)
+		c.pending = append([]byte(nil), c.pending[cut:]...)
+	}
+
+	return chunks
+}
+
+// findCutpoint scans data (a suffix of the file still awaiting chunking)
+// and returns the length of the next chunk, which is always in
+// [1, min(len(data), chunkMaxSize)] and is at least chunkMinSize unless
+// data itself is shorter than that.
+func findCutpoint(data []byte) int {
+	// If what remains is no longer than a minimal chunk, no cutpoint can fall
+	// inside it; the whole remainder is the next chunk.
+	if len(data) <= chunkMinSize {
+		return len(data)
+	}
+
+	limit := len(data)
+	if limit > chunkMaxSize {
+		limit = chunkMaxSize
+	}
+
+	var h uint64
+	i := chunkMinSize
+	for ; i < limit; i++ {
+		h = (h << 1) + gearTable[data[i]]
+		if i < chunkTargetSize {
+			if h&chunkNormalizedMask == 0 {
+				return i + 1
+			}
+		} else {
+			if h&chunkReducedMask == 0 {
+				return i + 1
+			}
+		}
+	}
+
+	// No natural cutpoint found before the size limit; force a cut there.
+	return limit
+}
@@ -0,0 +1,75 @@
+package sync
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+)
+
+// chunkAll feeds data to a fresh chunker in pieces of the given size,
+// returning the resulting manifest.
+func chunkAll(data []byte, feedSize int) []Chunk {
+	var c chunker
+	var chunks []Chunk
+	for len(data) > 0 {
+		n := feedSize
+		if n > len(data) {
+			n = len(data)
+		}
+		chunks = c.feed(data[:n], false, chunks)
+		data = data[n:]
+	}
+	return c.feed(nil, true, chunks)
+}
+
+func TestChunkerFeedSizeIndependent(t *testing.T) {
+	data := make([]byte, 5*chunkMaxSize)
+	rand.New(rand.NewSource(1)).Read(data)
+
+	whole := chunkAll(data, len(data))
+	piecewise := chunkAll(data, 97)
+
+	if len(whole) != len(piecewise) {
+		t.Fatalf("chunk count differs by feed size: %d != %d", len(whole), len(piecewise))
+	}
+	for i := range whole {
+		if whole[i].Offset != piecewise[i].Offset || whole[i].Length != piecewise[i].Length {
+			t.Fatalf("chunk %d boundary differs by feed size: %+v != %+v", i, whole[i], piecewise[i])
+		}
+		if !bytes.Equal(whole[i].Digest, piecewise[i].Digest) {
+			t.Fatalf("chunk %d digest differs by feed size", i)
+		}
+	}
+}
+
+func TestChunkerBoundariesShiftWithInsertion(t *testing.T) {
+	data := make([]byte, 3*chunkMaxSize)
+	rand.New(rand.NewSource(2)).Read(data)
+
+	original := chunkAll(data, len(data))
+
+	// Insert a few bytes well before the end and re-chunk. Content-defined
+	// chunking should leave most trailing chunks (those from after the
+	// rolling hash has resynchronized) with identical digests, unlike
+	// fixed-size blocks which would realign every chunk after the insertion.
+	inserted := append([]byte{}, data[:chunkMaxSize]...)
+	inserted = append(inserted, []byte("mutagen")...)
+	inserted = append(inserted, data[chunkMaxSize:]...)
+
+	shifted := chunkAll(inserted, len(inserted))
+
+	reused := 0
+	seen := make(map[string]bool, len(original))
+	for _, chunk := range original {
+		seen[string(chunk.Digest)] = true
+	}
+	for _, chunk := range shifted {
+		if seen[string(chunk.Digest)] {
+			reused++
+		}
+	}
+
+	if reused == 0 {
+		t.Fatal("expected at least some chunks to be reused after insertion")
+	}
+}
@@ -1,11 +1,15 @@
 package sync
 
 import (
+	"context"
 	"hash"
 	"io"
 	"os"
 	pathpkg "path"
 	"path/filepath"
+	"runtime"
+	"sync"
+	"time"
 
 	"github.com/pkg/errors"
 
@@ -25,16 +29,150 @@ const (
 	// rounds of cache capacity doubling on insert without always allocating a
 	// huge cache. Its value is somewhat arbitrary.
 	defaultInitialCacheCapacity = 1024
+
+	// scannerJobQueueSize specifies the capacity of the channel used to hand
+	// file-hashing jobs off to the worker pool. It's sized to allow directory
+	// traversal to run a little ahead of hashing without buffering an
+	// unbounded number of open-file-sized jobs.
+	scannerJobQueueSize = 16
 )
 
+// fileJob describes a single file awaiting hashing by the worker pool. entry
+// is the (already allocated, and returned to the traversal for insertion into
+// its parent's Contents map) Entry whose Digest should be filled in once
+// hashing completes.
+type fileJob struct {
+	path    string
+	mode    os.FileMode
+	modTime time.Time
+	size    uint64
+	entry   *Entry
+}
+
 type scanner struct {
 	root        string
-	hasher      hash.Hash
+	newHasher   func() hash.Hash
 	cache       *Cache
 	pathIgnorer *pathIgnorer
 	ignoreSize  uint64
-	newCache    *Cache
-	buffer      []byte
+
+	// newCache and newCacheLock guard the cache being populated for this
+	// scan. Unlike the old serial scanner, entries may be written to it
+	// concurrently by worker goroutines, so access must be synchronized.
+	newCache     *Cache
+	newCacheLock sync.Mutex
+
+	// ctx and cancel control the worker pool. cancel is invoked by fail as
+	// soon as the first error is observed, causing outstanding jobs to be
+	// abandoned instead of continuing to burn CPU/disk on a scan that's
+	// already failed.
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	// jobs is the channel used to dispatch file-hashing jobs to the worker
+	// pool. workers is the WaitGroup tracking their completion.
+	jobs    chan fileJob
+	workers sync.WaitGroup
+
+	// errOnce and err record the first error encountered by any worker (or
+	// by the traversal goroutine itself).
+	errOnce sync.Once
+	err     error
+}
+
+// fail records err as the scan's terminal error (if one hasn't already been
+// recorded) and cancels outstanding work.
+func (s *scanner) fail(err error) {
+	s.errOnce.Do(func() {
+		s.err = err
+		s.cancel()
+	})
+}
+
+// worker hashes files off the job queue until it's drained or the scan is
+// cancelled. Each worker maintains its own hash.Hash and copy buffer so that
+// workers don't contend with one another the way a single shared hasher and
+// buffer would.
+func (s *scanner) worker() {
+	defer s.workers.Done()
+
+	hasher := s.newHasher()
+	buffer := make([]byte, scannerCopyBufferSize)
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case job, ok := <-s.jobs:
+			if !ok {
+				return
+			}
+			if err := s.hash(job, hasher, buffer); err != nil {
+				s.fail(err)
+				return
+			}
+		}
+	}
+}
+
+// hash computes the whole-file digest and chunk manifest for job's file in a
+// single pass, storing both on job.entry/the new cache.
+func (s *scanner) hash(job fileJob, hasher hash.Hash, buffer []byte) error {
+	// Open the file and ensure its closure.
+	file, err := os.Open(filepath.Join(s.root, job.path))
+	if err != nil {
+		return errors.Wrap(err, "unable to open file")
+	}
+	defer file.Close()
+
+	// Reset the hash state.
+	hasher.Reset()
+
+	// Stream the file through both the whole-file hasher and the chunker in
+	// a single read pass, so that computing the chunk manifest doesn't
+	// require a second trip through the file.
+	var chunks []Chunk
+	var c chunker
+	var copied uint64
+	for {
+		n, readErr := file.Read(buffer)
+		if n > 0 {
+			hasher.Write(buffer[:n])
+			copied += uint64(n)
+			chunks = c.feed(buffer[:n], false, chunks)
+		}
+		if readErr == io.EOF {
+			break
+		} else if readErr != nil {
+			return errors.Wrap(readErr, "unable to hash file contents")
+		}
+	}
+	chunks = c.feed(nil, true, chunks)
+
+	if copied != job.size {
+		return errors.New("hashed size mismatch")
+	}
+
+	// Compute the digest.
+	digest := hasher.Sum(nil)
+
+	// Add a cache entry.
+	s.newCacheLock.Lock()
+	s.newCache.Entries[job.path] = &CacheEntry{
+		Mode:             uint32(job.mode),
+		ModificationTime: job.modTime,
+		Size_:            job.size,
+		Digest:           digest,
+		ChunkManifest:    chunks,
+	}
+	s.newCacheLock.Unlock()
+
+	// Fill in the entry. This is safe without further synchronization
+	// because the caller doesn't observe entry until after s.workers.Wait()
+	// has returned.
+	job.entry.Digest = digest
+
+	return nil
 }
 
 func (s *scanner) file(path string, info os.FileInfo) (*Entry, error) {
@@ -47,54 +185,58 @@ func (s *scanner) file(path string, info os.FileInfo) (*Entry, error) {
 	executable := (mode&AnyExecutablePermission != 0)
 
 	// Try to find a cached digest. We only enforce that type, modification
-	// time, and size haven't changed in order to re-use digests.
-	var digest []byte
+	// time, and size haven't changed in order to re-use digests. This fast
+	// path runs on the traversal goroutine and short-circuits before a
+	// worker is ever dispatched.
 	cached, hit := s.cache.Entries[path]
 	match := hit &&
 		(os.FileMode(cached.Mode)&os.ModeType) == (mode&os.ModeType) &&
 		modificationTime.Equal(cached.ModificationTime) &&
 		cached.Size_ == size
 	if match {
-		digest = cached.Digest
-	}
-
-	// If we weren't able to pull a digest from the cache, compute one manually.
-	if digest == nil {
-		// Open the file and ensure its closure.
-		file, err := os.Open(filepath.Join(s.root, path))
-		if err != nil {
-			return nil, errors.Wrap(err, "unable to open file")
+		s.newCacheLock.Lock()
+		s.newCache.Entries[path] = &CacheEntry{
+			Mode:             uint32(mode),
+			ModificationTime: modificationTime,
+			Size_:            size,
+			Digest:           cached.Digest,
+			// The chunk manifest is tied to the same (mode, mtime, size)
+			// triple we've already matched on, so it can be reused verbatim
+			// alongside the digest.
+			ChunkManifest: cached.ChunkManifest,
 		}
-		defer file.Close()
+		s.newCacheLock.Unlock()
 
-		// Reset the hash state.
-		s.hasher.Reset()
-
-		// Copy data into the hash and very that we copied as much as expected.
-		if copied, err := io.CopyBuffer(s.hasher, file, s.buffer); err != nil {
-			return nil, errors.Wrap(err, "unable to hash file contents")
-		} else if uint64(copied) != size {
-			return nil, errors.New("hashed size mismatch")
-		}
-
-		// Compute the digest.
-		digest = s.hasher.Sum(nil)
-	}
-
-	// Add a cache entry.
-	s.newCache.Entries[path] = &CacheEntry{
-		Mode:             uint32(mode),
-		ModificationTime: modificationTime,
-		Size_:            size,
-		Digest:           digest,
+		return &Entry{
+			Kind:       EntryKind_File,
+			Executable: executable,
+			Digest:     cached.Digest,
+		}, nil
 	}
 
-	// Success.
-	return &Entry{
+	// We weren't able to pull a digest from the cache, so dispatch a
+	// hashing job to the worker pool. The entry is returned immediately
+	// (with its Digest left unset) and filled in once the job completes; the
+	// caller must not inspect Digest until the scan as a whole has finished.
+	entry := &Entry{
 		Kind:       EntryKind_File,
 		Executable: executable,
-		Digest:     digest,
-	}, nil
+	}
+	job := fileJob{
+		path:    path,
+		mode:    mode,
+		modTime: modificationTime,
+		size:    size,
+		entry:   entry,
+	}
+	select {
+	case s.jobs <- job:
+	case <-s.ctx.Done():
+		return nil, s.ctx.Err()
+	}
+
+	// Success (pending completion by the worker pool).
+	return entry, nil
 }
 
 func (s *scanner) symlink(path string) (*Entry, error) {
@@ -114,10 +256,17 @@ func (s *scanner) symlink(path string) (*Entry, error) {
 }
 
 func (s *scanner) directory(path string) (*Entry, error) {
+	// Watch for cancellation from a failure observed by a worker.
+	if err := s.ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	// Read directory contents.
 	directoryContents, err := filesystem.DirectoryContents(filepath.Join(s.root, path))
 	if err != nil {
-		return nil, errors.Wrap(err, "unable to read directory contents")
+		err = errors.Wrap(err, "unable to read directory contents")
+		s.fail(err)
+		return nil, err
 	}
 
 	// Compute entries.
@@ -138,7 +287,9 @@ func (s *scanner) directory(path string) (*Entry, error) {
 			if os.IsNotExist(err) {
 				continue
 			}
-			return nil, errors.Wrap(err, "unable to stat directory content")
+			err = errors.Wrap(err, "unable to stat directory content")
+			s.fail(err)
+			return nil, err
 		}
 
 		// Compute the kind for this content, skipping if unsupported.
@@ -158,7 +309,9 @@ func (s *scanner) directory(path string) (*Entry, error) {
 			}
 		}
 
-		// Handle based on kind.
+		// Handle based on kind. File hashing is dispatched to the worker
+		// pool by s.file and doesn't block traversal; directories and
+		// symlinks are cheap enough that they're handled inline.
 		var entry *Entry
 		if kind == EntryKind_File {
 			entry, err = s.file(contentPath, info)
@@ -174,8 +327,13 @@ func (s *scanner) directory(path string) (*Entry, error) {
 			panic("unhandled entry kind")
 		}
 
-		// Watch for errors and add the entry.
+		// Watch for errors and add the entry. s.fail is a no-op if this error
+		// (or an earlier one from a worker) has already triggered
+		// cancellation; calling it here as well ensures that errors raised
+		// directly by traversal (as opposed to by a worker) also cancel
+		// outstanding hashing jobs instead of letting them run to completion.
 		if err != nil {
+			s.fail(err)
 			return nil, err
 		}
 
@@ -190,7 +348,12 @@ func (s *scanner) directory(path string) (*Entry, error) {
 	}, nil
 }
 
-func Scan(root string, hasher hash.Hash, cache *Cache, ignores []string, ignoreSize uint64) (*Entry, *Cache, error) {
+// Scan walks the contents at root, computing a snapshot Entry and an
+// updated Cache. File hashing is performed by a pool of concurrency worker
+// goroutines (defaulting to runtime.NumCPU() if concurrency is non-positive)
+// so that, on large trees, CPU and disk bandwidth aren't left idle behind a
+// single-threaded hasher.
+func Scan(root string, newHasher func() hash.Hash, cache *Cache, ignores []string, ignoreSize uint64, concurrency int) (*Entry, *Cache, error) {
 	// If the cache is nil, create an empty one.
 	if cache == nil {
 		cache = &Cache{}
@@ -211,38 +374,62 @@ func Scan(root string, hasher hash.Hash, cache *Cache, ignores []string, ignoreS
 	}
 	newCache := &Cache{make(map[string]*CacheEntry, initialCacheCapacity)}
 
+	// Determine the worker pool size.
+	if concurrency <= 0 {
+		concurrency = runtime.NumCPU()
+	}
+
 	// Create a scanner.
+	ctx, cancel := context.WithCancel(context.Background())
 	s := &scanner{
 		root:        root,
-		hasher:      hasher,
+		newHasher:   newHasher,
 		cache:       cache,
 		pathIgnorer: pathIgnorer,
 		ignoreSize:  ignoreSize,
 		newCache:    newCache,
-		buffer:      make([]byte, scannerCopyBufferSize),
+		ctx:         ctx,
+		cancel:      cancel,
+		jobs:        make(chan fileJob, scannerJobQueueSize),
+	}
+	defer s.cancel()
+
+	// Start the worker pool.
+	s.workers.Add(concurrency)
+	for i := 0; i < concurrency; i++ {
+		go s.worker()
 	}
 
 	// Create the snapshot. We use os.Stat, as opposed to os.Lstat, because we
 	// DO want to follow symbolic links at the root.
-	if info, err := os.Stat(root); err != nil {
-		if os.IsNotExist(err) {
-			return nil, newCache, nil
-		} else {
-			return nil, nil, errors.Wrap(err, "unable to probe snapshot root")
+	info, statErr := os.Stat(root)
+
+	var rootEntry *Entry
+	var traversalErr error
+	if statErr != nil {
+		if !os.IsNotExist(statErr) {
+			traversalErr = errors.Wrap(statErr, "unable to probe snapshot root")
 		}
 	} else if mode := info.Mode(); mode&os.ModeDir != 0 {
-		if rootEntry, err := s.directory(""); err != nil {
-			return nil, nil, err
-		} else {
-			return rootEntry, newCache, nil
-		}
+		rootEntry, traversalErr = s.directory("")
 	} else if mode&os.ModeType != 0 {
-		return nil, nil, errors.New("invalid snapshot root type")
+		traversalErr = errors.New("invalid snapshot root type")
 	} else {
-		if rootEntry, err := s.file("", info); err != nil {
-			return nil, nil, err
-		} else {
-			return rootEntry, newCache, nil
-		}
+		rootEntry, traversalErr = s.file("", info)
 	}
+
+	// Stop accepting new jobs and wait for the worker pool to drain, then
+	// check whether any worker (or the traversal itself) failed.
+	// s.err is checked first since, if a worker failed first, traversalErr is
+	// merely the resulting cancellation error.
+	close(s.jobs)
+	s.workers.Wait()
+	if s.err != nil {
+		return nil, nil, s.err
+	} else if traversalErr != nil {
+		return nil, nil, traversalErr
+	}
+
+	// Success.
+	return rootEntry, newCache, nil
 }
@@ -0,0 +1,65 @@
+package sync
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// generateSyntheticTree populates root with count small files spread across
+// subdirectories of 100 files each, returning root for convenience.
+func generateSyntheticTree(b *testing.B, root string, count int) string {
+	const filesPerDirectory = 100
+	content := []byte("mutagen benchmark content")
+
+	directory := root
+	for i := 0; i < count; i++ {
+		if i%filesPerDirectory == 0 {
+			directory = filepath.Join(root, fmt.Sprintf("dir%d", i/filesPerDirectory))
+			if err := os.MkdirAll(directory, 0700); err != nil {
+				b.Fatal("unable to create benchmark directory:", err)
+			}
+		}
+		path := filepath.Join(directory, fmt.Sprintf("file%d", i))
+		if err := ioutil.WriteFile(path, content, 0600); err != nil {
+			b.Fatal("unable to write benchmark file:", err)
+		}
+	}
+
+	return root
+}
+
+// benchmarkScan runs Scan over a synthetic tree of the given size with the
+// specified worker pool concurrency (0 meaning runtime.NumCPU()).
+func benchmarkScan(b *testing.B, fileCount, concurrency int) {
+	root, err := ioutil.TempDir("", "mutagen-scan-benchmark")
+	if err != nil {
+		b.Fatal("unable to create temporary directory:", err)
+	}
+	defer os.RemoveAll(root)
+
+	generateSyntheticTree(b, root, fileCount)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, _, err := Scan(root, sha1.New, nil, nil, 0, concurrency); err != nil {
+			b.Fatal("scan failed:", err)
+		}
+	}
+}
+
+// BenchmarkScanSerial100k exercises Scan against a 100k-file synthetic tree
+// with a single worker, representing the old fully-serial behavior.
+func BenchmarkScanSerial100k(b *testing.B) {
+	benchmarkScan(b, 100000, 1)
+}
+
+// BenchmarkScanParallel100k exercises Scan against the same 100k-file
+// synthetic tree with a runtime.NumCPU()-sized worker pool, to demonstrate
+// (and guard against regressions in) the speedup from parallel hashing.
+func BenchmarkScanParallel100k(b *testing.B) {
+	benchmarkScan(b, 100000, 0)
+}